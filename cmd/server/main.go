@@ -29,8 +29,19 @@ func main() {
 
 	log := logger.NewLogger(cfg.LogLevel, cfg.LogFormat)
 
+	cfgStore := config.NewStore(cfg)
+
+	if cfg.ConfigFile != "" {
+		watcher, err := config.WatchFile(cfg.ConfigFile, cfgStore, log)
+		if err != nil {
+			log.Error("Failed to watch config file", "file", cfg.ConfigFile, "error", err)
+			os.Exit(1)
+		}
+		defer watcher.Close()
+	}
+
 	log.Info("Starting HTTP server", "bind_address", cfg.BindAddress)
-	if err := api.StartServer(cfg, log); err != nil {
+	if err := api.StartServer(cfgStore, log); err != nil {
 		log.Error("Failed to start HTTP server", "error", err)
 		os.Exit(1)
 	}
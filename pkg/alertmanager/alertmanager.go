@@ -0,0 +1,117 @@
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/ycyr/splunk2alertmanager/pkg/config"
+)
+
+// SplunkWebhook is the payload Splunk's alert action sends when it fires a
+// webhook, keyed on the fields splunk2am actually uses.
+type SplunkWebhook struct {
+	SID         string                 `json:"sid"`
+	SearchName  string                 `json:"search_name"`
+	App         string                 `json:"app"`
+	Owner       string                 `json:"owner"`
+	ResultsLink string                 `json:"results_link"`
+	Result      map[string]interface{} `json:"result"`
+}
+
+// Alert is a single Alertmanager v2 alert, as accepted by
+// POST /api/v2/alerts.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    string            `json:"startsAt,omitempty"`
+	EndsAt      string            `json:"endsAt,omitempty"`
+}
+
+// HandleSplunkWebhook decodes a Splunk alert-action webhook, converts it
+// into an Alertmanager alert and queues it on pipeline for asynchronous,
+// batched delivery. It returns 202 once the alert is queued, or 429 if
+// pipeline's buffer is saturated.
+func HandleSplunkWebhook(w http.ResponseWriter, r *http.Request, cfg config.Config, pipeline *Pipeline, logger *slog.Logger) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload SplunkWebhook
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		logger.Error("failed to decode splunk webhook payload", "error", err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	alert := buildAlert(payload, cfg)
+
+	if !pipeline.Enqueue(cfg, alert) {
+		logger.Warn("alert buffer full, dropping alert", "search_name", payload.SearchName)
+		http.Error(w, "buffer full", http.StatusTooManyRequests)
+		return
+	}
+
+	logger.Info("queued alert for alertmanager", "search_name", payload.SearchName, "sid", payload.SID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// buildAlert converts a Splunk webhook payload into an Alertmanager alert,
+// deriving its labels and annotations by executing cfg's compiled
+// LabelTemplates/AnnotationTemplates against the payload's result fields.
+// A label template that renders empty is omitted rather than set to "",
+// since an empty-valued label would otherwise change the alert's identity
+// and grouping in Alertmanager, which drops empty labels before
+// fingerprinting. A result field that is absent does not render empty:
+// text/template prints it as "<no value>", and a label with that value is
+// kept. Templates that need a fallback for a missing field should use the
+// `default` func, e.g. `{{.result.severity | default "warning"}}`.
+// Annotations are kept as-is, empty or not.
+func buildAlert(payload SplunkWebhook, cfg config.Config) Alert {
+	data := map[string]interface{}{"result": payload.Result}
+
+	labels := map[string]string{
+		"alertname": payload.SearchName,
+	}
+	for name, tmpl := range cfg.CompiledLabelTemplates() {
+		if v := renderTemplate(tmpl, data); v != "" {
+			labels[name] = v
+		}
+	}
+
+	annotations := map[string]string{}
+	for name, tmpl := range cfg.CompiledAnnotationTemplates() {
+		annotations[name] = renderTemplate(tmpl, data)
+	}
+
+	alert := Alert{
+		Labels:      labels,
+		Annotations: annotations,
+		StartsAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if cfg.EndsAtDuration != "" {
+		if d, err := time.ParseDuration(cfg.EndsAtDuration); err == nil {
+			alert.EndsAt = time.Now().UTC().Add(d).Format(time.RFC3339)
+		}
+	}
+
+	return alert
+}
+
+// renderTemplate executes tmpl against data, returning an empty string if
+// execution fails. The template itself was already validated at config load
+// by Config.Validate, so a failure here comes from this particular result's
+// values, e.g. a field accessed on a value that is not a map. A field absent
+// from the result does not fail; it renders as "<no value>".
+func renderTemplate(tmpl *template.Template, data map[string]interface{}) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return ""
+	}
+	return buf.String()
+}
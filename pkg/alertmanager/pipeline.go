@@ -0,0 +1,286 @@
+package alertmanager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ycyr/splunk2alertmanager/pkg/config"
+	"github.com/ycyr/splunk2alertmanager/pkg/metrics"
+)
+
+// queuedAlert pairs an Alert with the per-request settings it needs to be
+// delivered with, captured at enqueue time so a later config hot-reload
+// can't change how an already-queued alert is sent.
+type queuedAlert struct {
+	alertmanagerURL string
+	gzipEnabled     bool
+	alert           Alert
+}
+
+// Pipeline decouples the HTTP webhook handler from Alertmanager delivery:
+// handlers enqueue alerts onto a bounded channel, and a pool of workers
+// batches them and POSTs each batch to Alertmanager with retries. It is
+// modeled on Docker's Splunk log driver, which buffers and batches log
+// events the same way.
+type Pipeline struct {
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+
+	queue      chan queuedAlert
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	wg              sync.WaitGroup
+	shutdown        chan struct{}
+	deadLetterCount atomic.Int64
+}
+
+// NewPipeline builds a Pipeline and starts cfg.WorkerCount worker
+// goroutines draining it.
+func NewPipeline(cfg config.Config, logger *slog.Logger) *Pipeline {
+	p := &Pipeline{
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		maxRetries:    cfg.MaxRetries,
+		queue:         make(chan queuedAlert, cfg.BufferMax),
+		httpClient:    newAlertmanagerClient(cfg, logger),
+		logger:        logger,
+		shutdown:      make(chan struct{}),
+	}
+
+	for i := 0; i < cfg.WorkerCount; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+
+	return p
+}
+
+// newAlertmanagerClient builds the http.Client used to POST batches to
+// Alertmanager, pinning an internal CA via cfg.CAPath/CAName if set,
+// mirroring the docker Splunk driver's splunk-capath/-caname/
+// -insecureskipverify options.
+func newAlertmanagerClient(cfg config.Config, logger *slog.Logger) *http.Client {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify} //nolint:gosec // opt-in via config
+
+	if cfg.CAPath != "" {
+		caCert, err := os.ReadFile(cfg.CAPath)
+		if err != nil {
+			logger.Error("failed to read CA file, falling back to system roots", "file", cfg.CAPath, "error", err)
+		} else {
+			pool := x509.NewCertPool()
+			if pool.AppendCertsFromPEM(caCert) {
+				tlsConfig.RootCAs = pool
+			} else {
+				logger.Error("no valid certificates found in CA file, falling back to system roots", "file", cfg.CAPath)
+			}
+		}
+	}
+
+	if cfg.CAName != "" {
+		tlsConfig.ServerName = cfg.CAName
+	}
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+}
+
+// Enqueue buffers alert for delivery using the Alertmanager URL and gzip
+// setting from cfg. It returns false without blocking if the buffer is
+// full, so callers can surface backpressure (e.g. HTTP 429) to Splunk.
+func (p *Pipeline) Enqueue(cfg config.Config, alert Alert) bool {
+	select {
+	case p.queue <- queuedAlert{alertmanagerURL: cfg.AlertmanagerURL, gzipEnabled: cfg.GzipEnabled, alert: alert}:
+		metrics.BufferDepth.Set(float64(len(p.queue)))
+		return true
+	default:
+		return false
+	}
+}
+
+// DeadLetterCount returns the number of alerts dropped after a delivery
+// failure, either a non-retryable one or one whose retries ran out.
+func (p *Pipeline) DeadLetterCount() int64 {
+	return p.deadLetterCount.Load()
+}
+
+// Shutdown signals the workers to flush and drain whatever is already
+// queued, and waits for all workers to exit or ctx to expire. It does not
+// stop Enqueue: an alert enqueued after the workers have drained the queue
+// is not delivered.
+func (p *Pipeline) Shutdown(ctx context.Context) {
+	close(p.shutdown)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		p.logger.Warn("pipeline shutdown timed out with alerts still queued")
+	}
+}
+
+func (p *Pipeline) worker() {
+	defer p.wg.Done()
+
+	batch := make([]queuedAlert, 0, p.batchSize)
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		p.send(batch)
+		batch = batch[:0]
+		metrics.BufferDepth.Set(float64(len(p.queue)))
+	}
+
+	for {
+		select {
+		case a := <-p.queue:
+			batch = append(batch, a)
+			if len(batch) >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.shutdown:
+			for {
+				select {
+				case a := <-p.queue:
+					batch = append(batch, a)
+					if len(batch) >= p.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send groups a batch by destination Alertmanager URL and gzip setting and
+// delivers each group as a single POST.
+func (p *Pipeline) send(batch []queuedAlert) {
+	type groupKey struct {
+		url         string
+		gzipEnabled bool
+	}
+	groups := make(map[groupKey][]Alert)
+	for _, a := range batch {
+		k := groupKey{url: a.alertmanagerURL, gzipEnabled: a.gzipEnabled}
+		groups[k] = append(groups[k], a.alert)
+	}
+
+	for k, alerts := range groups {
+		p.sendWithRetry(k.url, alerts, k.gzipEnabled)
+	}
+}
+
+// sendWithRetry POSTs alerts to alertmanagerURL, retrying with exponential
+// backoff on network errors and 5xx responses. Any other failure drops the
+// batch at once; a retryable one drops it after maxRetries retries
+// (maxRetries+1 attempts in all). Dropped alerts are counted against
+// deadLetterCount.
+func (p *Pipeline) sendWithRetry(alertmanagerURL string, alerts []Alert, gzipEnabled bool) {
+	backoff := time.Second
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		status, err := postAlerts(p.httpClient, alertmanagerURL, alerts, gzipEnabled)
+		metrics.AlertmanagerPostDuration.Observe(time.Since(start).Seconds())
+
+		if err == nil && status < 300 {
+			metrics.AlertsForwardedTotal.Add(float64(len(alerts)))
+			return
+		}
+
+		metrics.AlertmanagerPostFailuresTotal.WithLabelValues(failureReason(status, err)).Inc()
+
+		retryable := err != nil || status >= 500
+		if !retryable || attempt >= p.maxRetries {
+			p.deadLetterCount.Add(int64(len(alerts)))
+			metrics.DroppedAlertsTotal.Add(float64(len(alerts)))
+			p.logger.Error("dropping alert batch after delivery failure", "alerts", len(alerts), "status", status, "error", err, "attempts", attempt+1)
+			return
+		}
+
+		p.logger.Warn("failed to post alert batch, retrying", "attempt", attempt+1, "status", status, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// failureReason labels an Alertmanager POST failure for
+// alertmanager_post_failures_total.
+func failureReason(status int, err error) string {
+	if err != nil {
+		return "network_error"
+	}
+	return fmt.Sprintf("status_%d", status)
+}
+
+// postAlerts sends alerts to Alertmanager's v2 alerts API, optionally
+// gzip-compressing the body.
+func postAlerts(client *http.Client, alertmanagerURL string, alerts []Alert, gzipEnabled bool) (int, error) {
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling alerts: %w", err)
+	}
+
+	var reqBody io.Reader = bytes.NewReader(body)
+	contentEncoding := ""
+
+	if gzipEnabled {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(body); err != nil {
+			return 0, fmt.Errorf("gzip-encoding alerts: %w", err)
+		}
+		if err := gw.Close(); err != nil {
+			return 0, fmt.Errorf("gzip-encoding alerts: %w", err)
+		}
+		reqBody = &buf
+		contentEncoding = "gzip"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(alertmanagerURL, "/")+"/api/v2/alerts", reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("posting alerts: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
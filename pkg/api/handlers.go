@@ -1,18 +1,162 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"log/slog"
 
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"github.com/ycyr/splunk2alertmanager/pkg/alertmanager"
 	"github.com/ycyr/splunk2alertmanager/pkg/config"
+	"github.com/ycyr/splunk2alertmanager/pkg/metrics"
 )
 
-// StartServer starts the HTTP server
-func StartServer(cfg config.Config, logger *slog.Logger) error {
-	http.HandleFunc("/splunk-webhook", func(w http.ResponseWriter, r *http.Request) {
-		alertmanager.HandleSplunkWebhook(w, r, cfg, logger)
-	})
-	return http.ListenAndServe(cfg.BindAddress, nil)
+// shutdownTimeout bounds how long StartServer waits for in-flight
+// requests and the delivery pipeline's buffer to drain on
+// SIGTERM/SIGINT before giving up.
+const shutdownTimeout = 30 * time.Second
+
+// StartServer starts the HTTP(S) server and the asynchronous delivery
+// pipeline backing it. cfgStore is consulted on every request, so
+// per-request settings like AlertmanagerURL, tenant routing and the label/
+// annotation templates take effect immediately on a config hot-reload.
+// The Pipeline, however, is built once from the startup Config, so
+// BatchSize, FlushInterval, WorkerCount, BufferMax, MaxRetries and the
+// outbound Alertmanager client's CAPath/CAName/InsecureSkipVerify all
+// require a restart to change, exactly like BindAddress and the webhook
+// listener's TLS settings. On SIGTERM/SIGINT it gracefully shuts down the
+// server and drains the pipeline's buffer before returning.
+func StartServer(cfgStore *config.Store, logger *slog.Logger) error {
+	cfg := cfgStore.Get()
+	pipeline := alertmanager.NewPipeline(cfg, logger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/splunk-webhook", webhookHandler(cfgStore, pipeline, logger))
+	mux.HandleFunc("/splunk-webhook/{tenant}", webhookHandler(cfgStore, pipeline, logger))
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: cfg.BindAddress, Handler: mux}
+
+	if cfg.TLSCertFile != "" {
+		tlsConfig, err := buildServerTLSConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("building TLS config: %w", err)
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLSCertFile != "" {
+			err = server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		} else {
+			errCh <- nil
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-sigCh:
+		logger.Info("received shutdown signal, draining alert buffer")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error("error during http server shutdown", "error", err)
+		}
+		pipeline.Shutdown(ctx)
+		return nil
+	}
+}
+
+// buildServerTLSConfig builds the tls.Config for the webhook listener,
+// optionally requiring and verifying a client certificate (mTLS) against
+// cfg.ClientCAFile.
+func buildServerTLSConfig(cfg config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if cfg.ClientCAFile != "" {
+		caCert, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	if cfg.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// webhookHandler resolves the tenant for a request (if multi-tenancy is
+// configured) and forwards the webhook using that tenant's Config. Every
+// request gets a request ID, echoed back as X-Request-ID and attached to
+// every log line the request produces, so a single webhook call can be
+// correlated across the log, following the pattern the docker Splunk
+// driver uses for its event IDs.
+func webhookHandler(cfgStore *config.Store, pipeline *alertmanager.Pipeline, logger *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := uuid.New().String()
+		w.Header().Set("X-Request-ID", requestID)
+		reqLogger := logger.With("request_id", requestID)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		tenantCfg, tenantName, err := resolveTenant(r, cfgStore.Get(), reqLogger)
+		if err != nil {
+			switch {
+			case errors.Is(err, errUnauthorized):
+				http.Error(rec, "unauthorized", http.StatusUnauthorized)
+			case errors.Is(err, errUnknownTenant):
+				http.Error(rec, "unknown tenant", http.StatusNotFound)
+			default:
+				http.Error(rec, "bad request", http.StatusBadRequest)
+			}
+			metrics.WebhookRequestsTotal.WithLabelValues(tenantName, strconv.Itoa(rec.status)).Inc()
+			return
+		}
+
+		alertmanager.HandleSplunkWebhook(rec, r, tenantCfg, pipeline, reqLogger)
+		metrics.WebhookRequestsTotal.WithLabelValues(tenantName, strconv.Itoa(rec.status)).Inc()
+	}
+}
+
+// statusRecorder captures the status code written to an
+// http.ResponseWriter so it can be used as a metrics label after the
+// handler returns.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
 }
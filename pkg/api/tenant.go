@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ycyr/splunk2alertmanager/pkg/config"
+)
+
+var (
+	errUnknownTenant = errors.New("unknown tenant")
+	errUnauthorized  = errors.New("unauthorized")
+)
+
+// defaultTenant labels requests served without multi-tenancy enabled, for
+// the tenant label on webhook_requests_total.
+const defaultTenant = "default"
+
+// unknownTenant labels a request that failed to resolve to any tenant
+// (an unknown {tenant} path value, or a missing or non-matching bearer
+// token), for the tenant label on
+// webhook_requests_total. Without it, every unauthorized request would
+// report an empty tenant label, mixing unauthenticated traffic into a
+// blank series.
+const unknownTenant = "unknown"
+
+// resolveTenant determines which tenant a webhook request belongs to and
+// returns the Config to forward its alert with, along with the tenant's
+// name for metrics/logging.
+//
+// If no tenants are configured, the top-level Config is used
+// unconditionally so single-tenant deployments keep working unchanged. If
+// tenants are configured, the tenant is selected by the {tenant} path
+// value when present, otherwise by matching a bearer token in the
+// Authorization header against a tenant's Token, mirroring Splunk HEC's
+// token model.
+//
+// The returned name is always defaultTenant, a configured tenant's name or
+// the unknownTenant sentinel, never attacker-controlled input: an
+// unauthenticated caller could otherwise mint an unbounded number of
+// Prometheus label values by POSTing to /splunk-webhook/<random>. The
+// actual requested path value is only logged, at debug level.
+func resolveTenant(r *http.Request, cfg config.Config, logger *slog.Logger) (config.Config, string, error) {
+	if len(cfg.Tenants) == 0 {
+		return cfg, defaultTenant, nil
+	}
+
+	if name := r.PathValue("tenant"); name != "" {
+		tenant, ok := cfg.Tenants[name]
+		if !ok {
+			logger.Debug("unknown tenant requested", "tenant", name)
+			return config.Config{}, unknownTenant, errUnknownTenant
+		}
+		return cfg.WithTenant(tenant), name, nil
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		return config.Config{}, unknownTenant, errUnauthorized
+	}
+
+	for name, tenant := range cfg.Tenants {
+		if tenant.Token != "" && subtle.ConstantTimeCompare([]byte(tenant.Token), []byte(token)) == 1 {
+			return cfg.WithTenant(tenant), name, nil
+		}
+	}
+
+	return config.Config{}, unknownTenant, errUnauthorized
+}
+
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(auth, prefix)
+}
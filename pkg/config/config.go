@@ -4,7 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds the application configuration
@@ -12,25 +17,134 @@ var version string // set via -ldflags "-X github.com/ycyr/splunk2alertmanager/p
 
 type Config struct {
 	ShowVersion      bool
+	ConfigFile       string
 	AlertmanagerURL  string
 	BindAddress      string
 	LogLevel         string
 	LogFormat        string
-	EndsAtDuration   string
-	AdditionalLabels []string
-	AnnotationPrefix string // New field for annotation prefix
+	EndsAtDuration      string
+	LabelTemplates      map[string]string
+	AnnotationTemplates map[string]string
+	Tenants             map[string]TenantConfig
+
+	compiledLabelTemplates      map[string]*template.Template
+	compiledAnnotationTemplates map[string]*template.Template
+
+	// fileBase is the flag-derived Config captured before ConfigFile was
+	// first merged in, kept so a later hot-reload can re-apply the file
+	// onto a fresh copy of it instead of onto the currently running
+	// Config. Rebasing onto the running Config would make a key removed
+	// from the file "sticky", since applyFile only ever overlays
+	// non-nil fields.
+	fileBase *Config
+
+	BatchSize     int
+	FlushInterval time.Duration
+	BufferMax     int
+	WorkerCount   int
+	GzipEnabled   bool
+	MaxRetries    int
+
+	// TLS/mTLS for the webhook listener.
+	TLSCertFile       string
+	TLSKeyFile        string
+	ClientCAFile      string
+	RequireClientCert bool
+
+	// CA verification for the outbound Alertmanager client, named after
+	// the docker Splunk log driver's splunk-capath/-caname/-insecureskipverify.
+	CAPath             string
+	CAName             string
+	InsecureSkipVerify bool
+}
+
+// TenantConfig overrides the top-level Config for one tenant of a
+// multi-tenant deployment, selected either by the {tenant} path segment on
+// the webhook route or by Token, presented as a bearer token in the
+// Authorization header (mirroring Splunk HEC's per-token model).
+type TenantConfig struct {
+	AlertmanagerURL     string            `yaml:"alertmanagerURL" json:"alertmanagerURL"`
+	LabelTemplates      map[string]string `yaml:"labelTemplates" json:"labelTemplates"`
+	AnnotationTemplates map[string]string `yaml:"annotationTemplates" json:"annotationTemplates"`
+	EndsAtDuration      string            `yaml:"endsAtDuration" json:"endsAtDuration"`
+	Token               string            `yaml:"token" json:"token"`
+
+	compiledLabelTemplates      map[string]*template.Template
+	compiledAnnotationTemplates map[string]*template.Template
+}
+
+// WithTenant returns a copy of c with any fields set on t overlaid on top,
+// used to build the per-request Config for a resolved tenant.
+func (c Config) WithTenant(t TenantConfig) Config {
+	out := c
+	if t.AlertmanagerURL != "" {
+		out.AlertmanagerURL = t.AlertmanagerURL
+	}
+	if t.EndsAtDuration != "" {
+		out.EndsAtDuration = t.EndsAtDuration
+	}
+	if t.compiledLabelTemplates != nil {
+		out.compiledLabelTemplates = t.compiledLabelTemplates
+	}
+	if t.compiledAnnotationTemplates != nil {
+		out.compiledAnnotationTemplates = t.compiledAnnotationTemplates
+	}
+	return out
+}
+
+// FileConfig mirrors the subset of Config that can be supplied via
+// -c/--config, in either YAML or JSON. Fields left unset in the file keep
+// whatever value was already set from CLI flags.
+type FileConfig struct {
+	AlertmanagerURL     *string                 `yaml:"alertmanagerURL" json:"alertmanagerURL"`
+	BindAddress         *string                 `yaml:"bindAddress" json:"bindAddress"`
+	LogLevel            *string                 `yaml:"logLevel" json:"logLevel"`
+	LogFormat           *string                 `yaml:"logFormat" json:"logFormat"`
+	EndsAtDuration      *string                 `yaml:"endsAtDuration" json:"endsAtDuration"`
+	LabelTemplates      map[string]string       `yaml:"labelTemplates" json:"labelTemplates"`
+	AnnotationTemplates map[string]string       `yaml:"annotationTemplates" json:"annotationTemplates"`
+	Tenants             map[string]TenantConfig `yaml:"tenants" json:"tenants"`
+
+	BatchSize     *int    `yaml:"batchSize" json:"batchSize"`
+	FlushInterval *string `yaml:"flushInterval" json:"flushInterval"`
+	BufferMax     *int    `yaml:"bufferMax" json:"bufferMax"`
+	WorkerCount   *int    `yaml:"workerCount" json:"workerCount"`
+	GzipEnabled   *bool   `yaml:"gzipEnabled" json:"gzipEnabled"`
+	MaxRetries    *int    `yaml:"maxRetries" json:"maxRetries"`
+
+	TLSCertFile       *string `yaml:"tlsCertFile" json:"tlsCertFile"`
+	TLSKeyFile        *string `yaml:"tlsKeyFile" json:"tlsKeyFile"`
+	ClientCAFile      *string `yaml:"clientCAFile" json:"clientCAFile"`
+	RequireClientCert *bool   `yaml:"requireClientCert" json:"requireClientCert"`
+
+	CAPath             *string `yaml:"caPath" json:"caPath"`
+	CAName             *string `yaml:"caName" json:"caName"`
+	InsecureSkipVerify *bool   `yaml:"insecureSkipVerify" json:"insecureSkipVerify"`
 }
 
 // LoadConfig parses command-line flags and returns a Config struct
 func LoadConfig() Config {
 	versionFlag := flag.Bool("version", false, "Print the version and exit")
+	configFile := flag.String("c", "", "Path to a YAML or JSON config file, watched for changes (`-c`, `--config`)")
+	flag.StringVar(configFile, "config", "", "Path to a YAML or JSON config file, watched for changes (`-c`, `--config`)")
 	alertmanagerURL := flag.String("u", "http://localhost:9093", "URL of the Alertmanager instance (`-u`, `--alertmanager-url`)")
 	bindAddress := flag.String("b", "localhost:8080", "Bind address for the HTTP server (`-b`, `--bind`)")
 	logLevel := flag.String("l", "info", "Log level (debug, info, warn, error) (`-l`, `--log-level`)")
 	logFormat := flag.String("f", "text", "Log format (json or text) (`-f`, `--log-format`)")
 	endsAtDuration := flag.String("e", "", "Duration for EndsAt (e.g., 1h, 30m, 15s) (`-e`, `--ends-at`); leave empty for no EndsAt")
-	additionalLabels := flag.String("add-labels", "", "Comma-separated list of additional labels to include from the Splunk result (`--add-labels`)")
-	annotationPrefix := flag.String("p", "ann.", "Prefix for detecting annotations (`-p`, `--annotation-prefix`)") // Default = "ann."
+	batchSize := flag.Int("batch-size", 10, "Number of alerts to batch before flushing to Alertmanager (`--batch-size`)")
+	flushInterval := flag.Duration("flush-interval", 5*time.Second, "Max time to hold a partial batch before flushing it to Alertmanager (`--flush-interval`)")
+	bufferMax := flag.Int("buffer-max", 1000, "Maximum alerts buffered awaiting delivery before the webhook returns 429 (`--buffer-max`)")
+	workerCount := flag.Int("workers", 2, "Number of worker goroutines delivering batches to Alertmanager (`--workers`)")
+	gzipEnabled := flag.Bool("gzip", false, "Gzip-compress the outgoing POST body to Alertmanager (`--gzip`)")
+	maxRetries := flag.Int("max-retries", 3, "Maximum retry attempts for a failed batch before it is dropped (`--max-retries`)")
+	tlsCertFile := flag.String("tls-cert", "", "Path to a TLS certificate for the HTTPS webhook listener (`--tls-cert`)")
+	tlsKeyFile := flag.String("tls-key", "", "Path to the TLS private key for the HTTPS webhook listener (`--tls-key`)")
+	clientCAFile := flag.String("client-ca", "", "Path to a CA bundle used to verify client certificates for mTLS (`--client-ca`)")
+	requireClientCert := flag.Bool("require-client-cert", false, "Require and verify a client certificate on the webhook listener (mTLS) (`--require-client-cert`)")
+	caPath := flag.String("splunk-capath", "", "Path to a CA bundle used to verify the Alertmanager server certificate (`--splunk-capath`)")
+	caName := flag.String("splunk-caname", "", "Expected server name on the Alertmanager certificate (`--splunk-caname`)")
+	insecureSkipVerify := flag.Bool("splunk-insecureskipverify", false, "Skip TLS certificate verification when connecting to Alertmanager (`--splunk-insecureskipverify`)")
 
 	flag.Parse()
 	if *versionFlag {
@@ -38,15 +152,220 @@ func LoadConfig() Config {
 		os.Exit(0)
 	}
 
-	return Config{
-		ShowVersion:      *versionFlag,
-		AlertmanagerURL:  *alertmanagerURL,
-		BindAddress:      *bindAddress,
-		LogLevel:         *logLevel,
-		LogFormat:        *logFormat,
-		EndsAtDuration:   *endsAtDuration,
-		AdditionalLabels: strings.Split(*additionalLabels, ","),
-		AnnotationPrefix: *annotationPrefix,
+	cfg := Config{
+		ShowVersion:     *versionFlag,
+		ConfigFile:      *configFile,
+		AlertmanagerURL: *alertmanagerURL,
+		BindAddress:     *bindAddress,
+		LogLevel:        *logLevel,
+		LogFormat:       *logFormat,
+		EndsAtDuration:  *endsAtDuration,
+		BatchSize:       *batchSize,
+		FlushInterval:   *flushInterval,
+		BufferMax:       *bufferMax,
+		WorkerCount:     *workerCount,
+		GzipEnabled:     *gzipEnabled,
+		MaxRetries:      *maxRetries,
+
+		TLSCertFile:       *tlsCertFile,
+		TLSKeyFile:        *tlsKeyFile,
+		ClientCAFile:      *clientCAFile,
+		RequireClientCert: *requireClientCert,
+
+		CAPath:             *caPath,
+		CAName:             *caName,
+		InsecureSkipVerify: *insecureSkipVerify,
+	}
+
+	if cfg.ConfigFile != "" {
+		base := cfg
+		if err := cfg.mergeFile(cfg.ConfigFile); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load config file %q: %v\n", cfg.ConfigFile, err)
+			os.Exit(1)
+		}
+		cfg.fileBase = &base
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+
+	return cfg
+}
+
+// mergeFile loads a FileConfig from path and overlays any fields it sets
+// onto c.
+func (c *Config) mergeFile(path string) error {
+	fc, err := parseFile(path)
+	if err != nil {
+		return err
+	}
+	return c.applyFile(fc)
+}
+
+func (c *Config) applyFile(fc FileConfig) error {
+	if fc.AlertmanagerURL != nil {
+		c.AlertmanagerURL = *fc.AlertmanagerURL
+	}
+	if fc.BindAddress != nil {
+		c.BindAddress = *fc.BindAddress
+	}
+	if fc.LogLevel != nil {
+		c.LogLevel = *fc.LogLevel
+	}
+	if fc.LogFormat != nil {
+		c.LogFormat = *fc.LogFormat
+	}
+	if fc.EndsAtDuration != nil {
+		c.EndsAtDuration = *fc.EndsAtDuration
+	}
+	if fc.LabelTemplates != nil {
+		c.LabelTemplates = fc.LabelTemplates
+	}
+	if fc.AnnotationTemplates != nil {
+		c.AnnotationTemplates = fc.AnnotationTemplates
+	}
+	if fc.Tenants != nil {
+		c.Tenants = fc.Tenants
+	}
+	if fc.BatchSize != nil {
+		c.BatchSize = *fc.BatchSize
+	}
+	if fc.FlushInterval != nil {
+		d, err := time.ParseDuration(*fc.FlushInterval)
+		if err != nil {
+			return fmt.Errorf("flushInterval: %w", err)
+		}
+		c.FlushInterval = d
+	}
+	if fc.BufferMax != nil {
+		c.BufferMax = *fc.BufferMax
+	}
+	if fc.WorkerCount != nil {
+		c.WorkerCount = *fc.WorkerCount
+	}
+	if fc.GzipEnabled != nil {
+		c.GzipEnabled = *fc.GzipEnabled
+	}
+	if fc.MaxRetries != nil {
+		c.MaxRetries = *fc.MaxRetries
+	}
+	if fc.TLSCertFile != nil {
+		c.TLSCertFile = *fc.TLSCertFile
+	}
+	if fc.TLSKeyFile != nil {
+		c.TLSKeyFile = *fc.TLSKeyFile
+	}
+	if fc.ClientCAFile != nil {
+		c.ClientCAFile = *fc.ClientCAFile
+	}
+	if fc.RequireClientCert != nil {
+		c.RequireClientCert = *fc.RequireClientCert
+	}
+	if fc.CAPath != nil {
+		c.CAPath = *fc.CAPath
+	}
+	if fc.CAName != nil {
+		c.CAName = *fc.CAName
+	}
+	if fc.InsecureSkipVerify != nil {
+		c.InsecureSkipVerify = *fc.InsecureSkipVerify
+	}
+	return nil
+}
+
+// parseFile reads and decodes a config file as YAML whatever its extension;
+// since YAML is a superset of JSON, a JSON file decodes the same way.
+func parseFile(path string) (FileConfig, error) {
+	var fc FileConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fc, err
 	}
+
+	if err := yaml.Unmarshal(data, &fc); err != nil {
+		return fc, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+
+	return fc, nil
 }
 
+// Validate checks that a Config is self-consistent enough to run the
+// server with. It is called on initial load and before every hot-reload so
+// a bad edit to the config file never takes down the running service.
+func (c *Config) Validate() error {
+	if c.AlertmanagerURL == "" {
+		return fmt.Errorf("alertmanagerURL must not be empty")
+	}
+	if c.BindAddress == "" {
+		return fmt.Errorf("bindAddress must not be empty")
+	}
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		return fmt.Errorf("logFormat must be %q or %q, got %q", "json", "text", c.LogFormat)
+	}
+	if c.EndsAtDuration != "" {
+		if _, err := time.ParseDuration(c.EndsAtDuration); err != nil {
+			return fmt.Errorf("endsAtDuration: %w", err)
+		}
+	}
+	for name, tenant := range c.Tenants {
+		if tenant.EndsAtDuration != "" {
+			if _, err := time.ParseDuration(tenant.EndsAtDuration); err != nil {
+				return fmt.Errorf("tenant %q: endsAtDuration: %w", name, err)
+			}
+		}
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batchSize must be greater than zero")
+	}
+	if c.BufferMax <= 0 {
+		return fmt.Errorf("bufferMax must be greater than zero")
+	}
+	if c.WorkerCount <= 0 {
+		return fmt.Errorf("workerCount must be greater than zero")
+	}
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("flushInterval must be greater than zero")
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("maxRetries must not be negative")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("tlsCertFile and tlsKeyFile must be set together")
+	}
+	if c.RequireClientCert && c.ClientCAFile == "" {
+		return fmt.Errorf("requireClientCert requires clientCAFile to be set")
+	}
+	if err := c.compileTemplates(); err != nil {
+		return fmt.Errorf("compiling templates: %w", err)
+	}
+	return nil
+}
+
+// Store holds a Config behind an atomic pointer so StartServer's handlers
+// can read the current config on every request while a reload swaps in a
+// new one concurrently, without taking a lock on the hot path.
+type Store struct {
+	ptr atomic.Pointer[Config]
+}
+
+// NewStore creates a Store initialized with cfg.
+func NewStore(cfg Config) *Store {
+	s := &Store{}
+	s.ptr.Store(&cfg)
+	return s
+}
+
+// Get returns the current Config.
+func (s *Store) Get() Config {
+	return *s.ptr.Load()
+}
+
+// Set atomically replaces the current Config.
+func (s *Store) Set(cfg Config) {
+	s.ptr.Store(&cfg)
+}
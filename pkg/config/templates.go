@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// templateFuncs are available to every label/annotation template, enabling
+// the `{{.result.severity | default "warning"}}` pattern to fall back to a
+// default when a Splunk result field is absent.
+var templateFuncs = template.FuncMap{
+	"default": func(def string, val interface{}) string {
+		if val == nil {
+			return def
+		}
+		if s, ok := val.(string); ok && s == "" {
+			return def
+		}
+		return fmt.Sprintf("%v", val)
+	},
+}
+
+// compileTemplates parses LabelTemplates and AnnotationTemplates, plus any
+// per-tenant overrides, into their compiled form, following the
+// loggerutils.ParseLogTag pattern of parsing templates once up front
+// rather than on every alert. An invalid template is a validation error,
+// not a runtime one.
+func (c *Config) compileTemplates() error {
+	labels, err := compileTemplateSet("label", c.LabelTemplates)
+	if err != nil {
+		return err
+	}
+	annotations, err := compileTemplateSet("annotation", c.AnnotationTemplates)
+	if err != nil {
+		return err
+	}
+	c.compiledLabelTemplates = labels
+	c.compiledAnnotationTemplates = annotations
+
+	for name, tenant := range c.Tenants {
+		tenantLabels, err := compileTemplateSet("label", tenant.LabelTemplates)
+		if err != nil {
+			return fmt.Errorf("tenant %q: %w", name, err)
+		}
+		tenantAnnotations, err := compileTemplateSet("annotation", tenant.AnnotationTemplates)
+		if err != nil {
+			return fmt.Errorf("tenant %q: %w", name, err)
+		}
+		tenant.compiledLabelTemplates = tenantLabels
+		tenant.compiledAnnotationTemplates = tenantAnnotations
+		c.Tenants[name] = tenant
+	}
+
+	return nil
+}
+
+func compileTemplateSet(kind string, templates map[string]string) (map[string]*template.Template, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+
+	compiled := make(map[string]*template.Template, len(templates))
+	for name, text := range templates {
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s template %q: %w", kind, name, err)
+		}
+		compiled[name] = tmpl
+	}
+	return compiled, nil
+}
+
+// CompiledLabelTemplates returns c's compiled label templates, parsed once
+// at config load/reload.
+func (c Config) CompiledLabelTemplates() map[string]*template.Template {
+	return c.compiledLabelTemplates
+}
+
+// CompiledAnnotationTemplates returns c's compiled annotation templates,
+// parsed once at config load/reload.
+func (c Config) CompiledAnnotationTemplates() map[string]*template.Template {
+	return c.compiledAnnotationTemplates
+}
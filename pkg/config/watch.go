@@ -0,0 +1,99 @@
+package config
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchFile watches path for changes and, on every write, create or rename
+// event,
+// re-applies it onto the flag-derived base Config and swaps the result
+// into store. The watcher logs a structured event on every reload attempt;
+// an invalid file is rejected and the previously running Config is left in
+// place.
+//
+// WatchFile watches path's parent directory rather than path itself and
+// filters events down to path's basename (and the ConfigMap `..data`
+// symlink), since watching the file
+// directly stops seeing events once the watched inode is replaced -
+// exactly what happens on an atomic save (vim, `sed -i`) or a Kubernetes
+// ConfigMap update (the `..data` symlink swap), both of which are a
+// rename/remove of the old path rather than a write to it.
+//
+// The returned *fsnotify.Watcher should be closed by the caller on
+// shutdown.
+func WatchFile(path string, store *Store, logger *slog.Logger) (*fsnotify.Watcher, error) {
+	dir := filepath.Dir(path)
+	name := filepath.Base(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if base := filepath.Base(event.Name); base != name && base != "..data" {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				reload(path, store, logger)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Error("config watcher error", "error", err, "file", path)
+			}
+		}
+	}()
+
+	return watcher, nil
+}
+
+// reload re-parses path, applies it onto a fresh copy of the flag-derived
+// base Config captured at startup and, if the result validates, swaps it
+// into store. Rebasing onto the base Config rather than the currently
+// running one means a key removed from the file actually reverts, since
+// applyFile only ever overlays fields the file sets.
+func reload(path string, store *Store, logger *slog.Logger) {
+	base := store.Get().fileBase
+	if base == nil {
+		logger.Error("config reload skipped: no base config to rebase onto", "file", path)
+		return
+	}
+	cfg := *base
+
+	fc, err := parseFile(path)
+	if err != nil {
+		logger.Error("config reload failed", "file", path, "error", err)
+		return
+	}
+
+	if err := cfg.applyFile(fc); err != nil {
+		logger.Error("config reload rejected: invalid config", "file", path, "error", err)
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		logger.Error("config reload rejected: invalid config", "file", path, "error", err)
+		return
+	}
+
+	cfg.fileBase = base
+	store.Set(cfg)
+	logger.Info("config reloaded", "file", path)
+}
@@ -0,0 +1,52 @@
+// Package metrics defines the Prometheus collectors exposed by splunk2am
+// at /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// WebhookRequestsTotal counts incoming Splunk webhook requests by
+	// tenant and the HTTP status returned.
+	WebhookRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_requests_total",
+		Help: "Total number of Splunk webhook requests received, by tenant and response status.",
+	}, []string{"tenant", "status"})
+
+	// AlertmanagerPostDuration tracks how long POSTs to Alertmanager take.
+	AlertmanagerPostDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "alertmanager_post_duration_seconds",
+		Help:    "Duration of POST requests to Alertmanager.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	// AlertmanagerPostFailuresTotal counts failed POSTs to Alertmanager by
+	// failure reason (e.g. a network error or an HTTP status code).
+	AlertmanagerPostFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alertmanager_post_failures_total",
+		Help: "Total number of failed POST requests to Alertmanager, by reason.",
+	}, []string{"reason"})
+
+	// AlertsForwardedTotal counts alerts successfully delivered to
+	// Alertmanager.
+	AlertsForwardedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerts_forwarded_total",
+		Help: "Total number of alerts successfully forwarded to Alertmanager.",
+	})
+
+	// BufferDepth reports how many alerts are currently queued awaiting
+	// delivery.
+	BufferDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "buffer_depth",
+		Help: "Current number of alerts buffered awaiting delivery to Alertmanager.",
+	})
+
+	// DroppedAlertsTotal counts alerts dropped after a delivery failure,
+	// either a non-retryable one or one whose retries ran out.
+	DroppedAlertsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dropped_alerts_total",
+		Help: "Total number of alerts dropped after a non-retryable delivery failure or exhausted delivery retries.",
+	})
+)